@@ -0,0 +1,113 @@
+// Package browser renders kinopoisk.ru pages in a headless Chrome
+// instance, for the cases where a plain HTTP GET returns a Cloudflare
+// challenge instead of the page content.
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const cookieDomain = ".kinopoisk.ru"
+
+// LoginURL is the page Login opens for the user to sign in on.
+const LoginURL = "https://www.kinopoisk.ru/"
+
+// Render launches a headless Chrome, sets cookie as the session cookie,
+// navigates to url, waits for selector to appear in the DOM (giving any
+// Cloudflare challenge time to resolve), and returns the rendered HTML.
+func Render(ctx context.Context, url, cookie, selector string, timeout time.Duration) (string, error) {
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, chromedp.DefaultExecAllocatorOptions[:]...)
+	defer cancelAlloc()
+
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	defer cancelBrowser()
+
+	browserCtx, cancelTimeout := context.WithTimeout(browserCtx, timeout)
+	defer cancelTimeout()
+
+	var rendered string
+	tasks := chromedp.Tasks{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return network.Enable().Do(ctx)
+		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return setCookies(ctx, cookie)
+		}),
+		chromedp.Navigate(url),
+		chromedp.WaitVisible(selector, chromedp.ByQuery),
+		chromedp.OuterHTML("html", &rendered, chromedp.ByQuery),
+	}
+
+	if err := chromedp.Run(browserCtx, tasks); err != nil {
+		return "", fmt.Errorf("render %s: %w", url, err)
+	}
+	return rendered, nil
+}
+
+// Login opens a visible (non-headless) Chrome at LoginURL so the user can
+// sign in by hand, calls wait once the window is open (typically to block
+// on a keypress after the user has finished logging in), then reads back
+// the session cookies Chrome ended up with and returns them formatted as
+// a Cookie header, for use as Exporter.Cookie or Importer.Cookie without
+// ever pasting one out of devtools.
+func Login(ctx context.Context, wait func()) (string, error) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.Flag("headless", false))
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, opts...)
+	defer cancelAlloc()
+
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	defer cancelBrowser()
+
+	if err := chromedp.Run(browserCtx, chromedp.Navigate(LoginURL)); err != nil {
+		return "", fmt.Errorf("open %s: %w", LoginURL, err)
+	}
+
+	wait()
+
+	var cookies []*network.Cookie
+	err := chromedp.Run(browserCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = network.GetCookies().WithUrls([]string{LoginURL}).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return "", fmt.Errorf("read session cookies: %w", err)
+	}
+
+	pairs := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		pairs = append(pairs, c.Name+"="+c.Value)
+	}
+	return strings.Join(pairs, "; "), nil
+}
+
+// setCookies installs the semicolon-separated "name=value" pairs of cookie
+// header as cookies scoped to kinopoisk.ru, the same way a browser would
+// have set them after a login.
+func setCookies(ctx context.Context, cookie string) error {
+	for _, pair := range strings.Split(cookie, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		err := network.SetCookie(parts[0], parts[1]).
+			WithDomain(cookieDomain).
+			WithPath("/").
+			Do(ctx)
+		if err != nil {
+			return fmt.Errorf("set cookie %s: %w", parts[0], err)
+		}
+	}
+	return nil
+}
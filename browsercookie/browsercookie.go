@@ -0,0 +1,118 @@
+// Package browsercookie reads the kinopoisk.ru / yandex.ru session cookies
+// out of an installed browser's own cookie store, so the user doesn't have
+// to copy the Cookie header out of devtools by hand.
+package browsercookie
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// domains are the cookie domains kinopoisk.ru's session relies on.
+var domains = []string{"kinopoisk.ru", "yandex.ru"}
+
+// Source identifies a browser (and, optionally, a specific profile) to
+// read cookies from.
+type Source struct {
+	Browser string // "firefox" or "chrome"
+	Profile string // profile name, or an explicit profile directory
+}
+
+// ParseSource parses a "-cookies-from" flag value of the form
+// "firefox[:profile]" or "chrome[:profile]". profile may be a bare profile
+// name (resolved under the browser's default config directory) or an
+// explicit path, e.g. "firefox:/home/alice/.mozilla/firefox/xxxx.alice".
+func ParseSource(flagValue string) (Source, error) {
+	browser, profile, _ := strings.Cut(flagValue, ":")
+	switch browser {
+	case "firefox", "chrome":
+	default:
+		return Source{}, fmt.Errorf("unsupported browser %q (want firefox or chrome)", browser)
+	}
+	return Source{Browser: browser, Profile: profile}, nil
+}
+
+// Load reads the kinopoisk.ru/yandex.ru cookies out of the source browser's
+// profile and returns them assembled into a Cookie header value, e.g.
+// "name1=value1; name2=value2".
+func Load(source Source) (string, error) {
+	profileDir, err := source.profileDir()
+	if err != nil {
+		return "", err
+	}
+
+	var cookies []cookie
+	switch source.Browser {
+	case "firefox":
+		cookies, err = readFirefoxCookies(profileDir)
+	case "chrome":
+		cookies, err = readChromeCookies(profileDir)
+	default:
+		return "", fmt.Errorf("unsupported browser %q", source.Browser)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(cookies) == 0 {
+		return "", fmt.Errorf("no kinopoisk.ru/yandex.ru cookies found in %s profile %s", source.Browser, profileDir)
+	}
+
+	var b strings.Builder
+	for i, c := range cookies {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "%s=%s", c.name, c.value)
+	}
+	return b.String(), nil
+}
+
+type cookie struct {
+	name  string
+	value string
+}
+
+func matchesDomain(host string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Source) profileDir() (string, error) {
+	if s.Profile != "" && (filepath.IsAbs(s.Profile) || strings.HasPrefix(s.Profile, ".") || strings.ContainsRune(s.Profile, os.PathSeparator)) {
+		return s.Profile, nil
+	}
+	return defaultProfileDir(s.Browser, s.Profile)
+}
+
+// copyLocked copies the sqlite file at path into a temp file and returns
+// its path, so it can be opened read-only even while the browser holds an
+// exclusive lock on the original. The caller is responsible for removing
+// the returned path.
+func copyLocked(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open %s (is the browser running with a lock on it?): %w", path, err)
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "kinopoisk-export-cookies-*.sqlite")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("copy %s: %w", path, err)
+	}
+
+	return tmp.Name(), nil
+}
@@ -0,0 +1,121 @@
+package browsercookie
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/crypto/pbkdf2"
+	_ "modernc.org/sqlite"
+)
+
+// linuxV10Password is the fixed password Chromium falls back to on Linux
+// when no OS keyring is available to store the real one (the "v10"
+// encryption scheme, as opposed to "v11" which is keyring-backed).
+const linuxV10Password = "peanuts"
+
+// readChromeCookies reads the kinopoisk.ru/yandex.ru cookies out of a
+// Chrome profile's "Cookies" sqlite database, decrypting the
+// encrypted_value column. Only the Linux "v10" scheme (a fixed PBKDF2
+// password, used when no keyring is available) is supported; macOS
+// Keychain- and Windows DPAPI-backed encryption are not.
+func readChromeCookies(profileDir string) ([]cookie, error) {
+	dbPath := filepath.Join(profileDir, "Cookies")
+
+	tmpPath, err := copyLocked(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+
+	db, err := sql.Open("sqlite", tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", dbPath, err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT host_key, name, encrypted_value FROM cookies`)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", dbPath, err)
+	}
+	defer rows.Close()
+
+	key, err := chromeDecryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var cookies []cookie
+	for rows.Next() {
+		var host, name string
+		var encrypted []byte
+		if err := rows.Scan(&host, &name, &encrypted); err != nil {
+			return nil, err
+		}
+		if !matchesDomain(host) {
+			continue
+		}
+
+		value, err := decryptChromeValue(encrypted, key)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt cookie %s for %s: %w", name, host, err)
+		}
+		cookies = append(cookies, cookie{name: name, value: value})
+	}
+	return cookies, rows.Err()
+}
+
+func chromeDecryptionKey() ([]byte, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("reading encrypted chrome cookies is only supported on linux (v10 scheme); %s uses OS-keychain-backed encryption", runtime.GOOS)
+	}
+	return pbkdf2.Key([]byte(linuxV10Password), []byte("saltysalt"), 1, 16, sha1.New), nil
+}
+
+// decryptChromeValue decrypts an encrypted_value blob using the v10
+// scheme: a "v10" prefix followed by AES-128-CBC ciphertext with a fixed
+// all-spaces IV.
+func decryptChromeValue(encrypted []byte, key []byte) (string, error) {
+	const prefixLen = 3 // "v10" or "v11"
+	if len(encrypted) <= prefixLen {
+		return "", fmt.Errorf("encrypted value too short")
+	}
+	prefix := string(encrypted[:prefixLen])
+	if prefix != "v10" {
+		return "", fmt.Errorf("unsupported encryption scheme %q", prefix)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := bytes.Repeat([]byte{' '}, aes.BlockSize)
+	ciphertext := encrypted[prefixLen:]
+	if len(ciphertext)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("ciphertext is not a multiple of the block size")
+	}
+
+	decrypted := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, ciphertext)
+
+	decrypted = trimPKCS7(decrypted)
+	return string(decrypted), nil
+}
+
+func trimPKCS7(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	padLen := int(b[len(b)-1])
+	if padLen <= 0 || padLen > len(b) {
+		return b
+	}
+	return b[:len(b)-padLen]
+}
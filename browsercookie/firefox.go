@@ -0,0 +1,47 @@
+package browsercookie
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// readFirefoxCookies reads the kinopoisk.ru/yandex.ru cookies out of a
+// Firefox profile's cookies.sqlite, which stores cookie values in plain
+// text.
+func readFirefoxCookies(profileDir string) ([]cookie, error) {
+	dbPath := filepath.Join(profileDir, "cookies.sqlite")
+
+	tmpPath, err := copyLocked(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+
+	db, err := sql.Open("sqlite", tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", dbPath, err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT host, name, value FROM moz_cookies`)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", dbPath, err)
+	}
+	defer rows.Close()
+
+	var cookies []cookie
+	for rows.Next() {
+		var host, name, value string
+		if err := rows.Scan(&host, &name, &value); err != nil {
+			return nil, err
+		}
+		if matchesDomain(host) {
+			cookies = append(cookies, cookie{name: name, value: value})
+		}
+	}
+	return cookies, rows.Err()
+}
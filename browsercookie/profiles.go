@@ -0,0 +1,75 @@
+package browsercookie
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// defaultProfileDir resolves a bare profile name (or, if empty, the
+// browser's default profile) under the browser's standard config
+// directory for the current OS.
+func defaultProfileDir(browser, profile string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	switch browser {
+	case "firefox":
+		return firefoxProfileDir(home, profile)
+	case "chrome":
+		return chromeProfileDir(home, profile)
+	default:
+		return "", fmt.Errorf("unsupported browser %q", browser)
+	}
+}
+
+func firefoxProfileDir(home, profile string) (string, error) {
+	var base string
+	switch runtime.GOOS {
+	case "darwin":
+		base = filepath.Join(home, "Library", "Application Support", "Firefox", "Profiles")
+	case "windows":
+		base = filepath.Join(os.Getenv("APPDATA"), "Mozilla", "Firefox", "Profiles")
+	default:
+		base = filepath.Join(home, ".mozilla", "firefox")
+	}
+
+	if profile != "" {
+		return filepath.Join(base, profile), nil
+	}
+	return findProfile(base, "*.default*")
+}
+
+func chromeProfileDir(home, profile string) (string, error) {
+	var base string
+	switch runtime.GOOS {
+	case "darwin":
+		base = filepath.Join(home, "Library", "Application Support", "Google", "Chrome")
+	case "windows":
+		base = filepath.Join(os.Getenv("LOCALAPPDATA"), "Google", "Chrome", "User Data")
+	default:
+		base = filepath.Join(home, ".config", "google-chrome")
+	}
+
+	if profile == "" {
+		profile = "Default"
+	}
+	return filepath.Join(base, profile), nil
+}
+
+// findProfile returns the first directory (in lexical order) under base
+// matching glob, which is how Firefox names its default profile directory
+// (<salt>.default or <salt>.default-release). Other matches are ignored.
+func findProfile(base, glob string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(base, glob))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no profile matching %s found under %s", glob, base)
+	}
+	return matches[0], nil
+}
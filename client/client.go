@@ -0,0 +1,61 @@
+// Package client provides the HTTP client used to talk to kinopoisk.ru and
+// its GraphQL backend, carrying the session cookie and the headers the
+// site expects.
+package client
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+
+// defaultTimeout bounds a single request so a hung connection can be
+// retried instead of blocking a worker forever.
+const defaultTimeout = 30 * time.Second
+
+// Client issues authenticated requests against kinopoisk.ru on behalf of a
+// single user session.
+type Client struct {
+	// Cookie is the raw Cookie header copied from a logged-in browser
+	// session.
+	Cookie string
+	// HTTPClient is used to perform requests. If nil, a client with
+	// defaultTimeout is used.
+	HTTPClient *http.Client
+}
+
+// New returns a Client for the given cookie, using the default timeout.
+func New(cookie string) *Client {
+	return &Client{Cookie: cookie}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return &http.Client{Timeout: defaultTimeout}
+}
+
+// Do performs an HTTP request against url with the given method and body,
+// adding the headers kinopoisk.ru expects (user agent, cookie, and, for
+// requests with a body, the GraphQL-specific headers).
+func (c *Client) Do(method, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("User-Agent", userAgent)
+	req.Header.Add("Cookie", c.Cookie)
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Origin", "https://www.kinopoisk.ru")
+		req.Header.Add("Referer", "https://www.kinopoisk.ru/")
+		req.Header.Add("Service-Id", "25")
+		req.Header.Add("Source-Id", "1")
+	}
+
+	return c.httpClient().Do(req)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	kinopoisk "github.com/v-electrolux/kinopoisk-export"
+	"github.com/v-electrolux/kinopoisk-export/browser"
+	"github.com/v-electrolux/kinopoisk-export/browsercookie"
+	"github.com/v-electrolux/kinopoisk-export/store"
+)
+
+func main() {
+	var outputFilename = flag.String("o", "", "path to output file with dumped movies")
+	var cookie = flag.String("c", "", "cookie header (you must copy it from browser); optional with -browser=chromedp, which logs in interactively instead")
+	var inputFilename = flag.String("i", "", "path to file with movies")
+	var userId = flag.String("u", "", "kinopoisk user id")
+	var formatFlag = flag.String("format", "csv", "output/input format: csv, tsv, json or xml")
+	var dbPath = flag.String("db", "", "path to SQLite database used as the canonical store of watched movies")
+	var dbInfo = flag.Bool("dbinfo", false, "print movie counts and last sync time for -db and exit")
+	var concurrency = flag.Int("concurrency", 1, "number of pages/movies processed at once")
+	var rate = flag.Float64("rate", 0, "max requests per second (0 = unthrottled)")
+	var browserFlag = flag.String("browser", "", "fall back to rendering pages with a headless browser when a plain fetch fails (supported: chromedp)")
+	var cookiesFrom = flag.String("cookies-from", "", "read the cookie header from an installed browser instead of -c (e.g. firefox, chrome:Profile 1, firefox:/path/to/profile)")
+	var enrichFlag = flag.String("enrich", "", "resolve IMDb/TMDb ids for each movie before writing output (supported: tmdb, requires -tmdb-key)")
+	var tmdbKey = flag.String("tmdb-key", "", "TMDb v3 API key, required when -enrich is set")
+	var letterboxdOutput = flag.String("letterboxd-output", "", "path to write a Letterboxd-compatible import CSV, requires -enrich")
+	var traktOutput = flag.String("trakt-output", "", "path to write a Trakt-compatible import JSON, requires -enrich")
+	flag.Parse()
+
+	switch {
+	case *cookiesFrom != "":
+		source, err := browsercookie.ParseSource(*cookiesFrom)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fromBrowser, err := browsercookie.Load(source)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*cookie = fromBrowser
+	case *cookie == "" && *browserFlag == kinopoisk.BrowserChromedp:
+		fmt.Println("Opening a browser window — log in to kinopoisk.ru, then press Enter here.")
+		loggedIn, err := browser.Login(context.Background(), func() {
+			bufio.NewReader(os.Stdin).ReadString('\n')
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		*cookie = loggedIn
+	}
+
+	var db *store.Store
+	if *dbPath != "" {
+		var err error
+		db, err = store.Open(*dbPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+	}
+
+	switch {
+	case *dbInfo:
+		if db == nil {
+			log.Fatal("-dbinfo requires -db")
+		}
+		info, err := db.Info()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Movies in db: ", info.MovieCount)
+		fmt.Println("Marked watched: ", info.WatchedSetCount)
+		fmt.Println("Last synced at: ", info.LastSyncedAt)
+	case *outputFilename != "":
+		out, err := os.Create(*outputFilename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+
+		exporter := kinopoisk.Exporter{
+			UserID:            *userId,
+			Cookie:            *cookie,
+			Output:            out,
+			Format:            *formatFlag,
+			DB:                db,
+			Concurrency:       *concurrency,
+			RequestsPerSecond: *rate,
+			Browser:           *browserFlag,
+			Enrich:            *enrichFlag,
+			TMDbAPIKey:        *tmdbKey,
+		}
+
+		if *letterboxdOutput != "" {
+			letterboxdFile, err := os.Create(*letterboxdOutput)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer letterboxdFile.Close()
+			exporter.LetterboxdOutput = letterboxdFile
+		}
+		if *traktOutput != "" {
+			traktFile, err := os.Create(*traktOutput)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer traktFile.Close()
+			exporter.TraktOutput = traktFile
+		}
+
+		if err := exporter.Export(); err != nil {
+			log.Fatal(err)
+		}
+	case *inputFilename != "":
+		in, err := os.Open(*inputFilename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer in.Close()
+
+		importer := kinopoisk.Importer{
+			Cookie:            *cookie,
+			Input:             in,
+			Format:            *formatFlag,
+			DB:                db,
+			Concurrency:       *concurrency,
+			RequestsPerSecond: *rate,
+		}
+		if err := importer.Import(); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		flag.PrintDefaults()
+	}
+}
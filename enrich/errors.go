@@ -0,0 +1,7 @@
+package enrich
+
+import "errors"
+
+// ErrNotFound is returned when a movie could not be matched in the target
+// catalog.
+var ErrNotFound = errors.New("movie not found")
@@ -0,0 +1,47 @@
+package enrich
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/v-electrolux/kinopoisk-export/model"
+)
+
+// letterboxdDateLayout is the YYYY-MM-DD format Letterboxd's importer
+// expects for WatchedDate.
+const letterboxdDateLayout = "2006-01-02"
+
+// WriteLetterboxd writes movies as a Letterboxd-compatible import CSV
+// (imdbID,Title,Year,Rating,WatchedDate). Movies without an ImdbID are
+// skipped, since Letterboxd's importer matches on it.
+func WriteLetterboxd(w io.Writer, movies []model.Movie) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"imdbID", "Title", "Year", "Rating", "WatchedDate"}); err != nil {
+		return err
+	}
+
+	for _, movie := range movies {
+		if movie.ImdbID == "" {
+			continue
+		}
+
+		record := []string{movie.ImdbID, movie.Title, "", "", ""}
+		if movie.Year != 0 {
+			record[2] = strconv.Itoa(movie.Year)
+		}
+		if movie.UserRating != 0 {
+			record[3] = strconv.Itoa(movie.UserRating)
+		}
+		if movie.WatchDate != nil {
+			record[4] = movie.WatchDate.Format(letterboxdDateLayout)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,108 @@
+// Package enrich maps kinopoisk movies onto other catalogs (TMDb, IMDb)
+// and writes the result in formats other trackers understand, turning a
+// kinopoisk backup into a migration utility for Letterboxd/Trakt.
+package enrich
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const tmdbAPIBaseURL = "https://api.themoviedb.org/3"
+
+// TMDb resolves a movie's TMDb and IMDb ids from its title and year.
+type TMDb struct {
+	// APIKey is a TMDb v3 API key.
+	APIKey string
+	// HTTPClient is used to perform requests. If nil, http.DefaultClient
+	// is used.
+	HTTPClient *http.Client
+}
+
+func (t *TMDb) httpClient() *http.Client {
+	if t.HTTPClient != nil {
+		return t.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+type searchResponse struct {
+	Results []struct {
+		ID int `json:"id"`
+	} `json:"results"`
+}
+
+type externalIDsResponse struct {
+	ImdbID string `json:"imdb_id"`
+}
+
+// Find looks up title (tried as the original title first, then the
+// Russian title) restricted to year, and returns the matching TMDb id and
+// its IMDb id. It returns ErrNotFound if no TMDb result matched.
+func (t *TMDb) Find(title, originalTitle string, year int) (tmdbID int, imdbID string, err error) {
+	for _, candidate := range []string{originalTitle, title} {
+		if candidate == "" {
+			continue
+		}
+		tmdbID, err = t.searchMovie(candidate, year)
+		if err != nil {
+			return 0, "", err
+		}
+		if tmdbID != 0 {
+			break
+		}
+	}
+	if tmdbID == 0 {
+		return 0, "", ErrNotFound
+	}
+
+	imdbID, err = t.externalIDs(tmdbID)
+	if err != nil {
+		return 0, "", err
+	}
+	return tmdbID, imdbID, nil
+}
+
+func (t *TMDb) searchMovie(query string, year int) (int, error) {
+	reqURL := fmt.Sprintf("%s/search/movie?api_key=%s&query=%s",
+		tmdbAPIBaseURL, url.QueryEscape(t.APIKey), url.QueryEscape(query))
+	if year != 0 {
+		reqURL += fmt.Sprintf("&year=%d", year)
+	}
+
+	var result searchResponse
+	if err := t.get(reqURL, &result); err != nil {
+		return 0, err
+	}
+	if len(result.Results) == 0 {
+		return 0, nil
+	}
+	return result.Results[0].ID, nil
+}
+
+func (t *TMDb) externalIDs(tmdbID int) (string, error) {
+	reqURL := fmt.Sprintf("%s/movie/%s/external_ids?api_key=%s",
+		tmdbAPIBaseURL, strconv.Itoa(tmdbID), url.QueryEscape(t.APIKey))
+
+	var result externalIDsResponse
+	if err := t.get(reqURL, &result); err != nil {
+		return "", err
+	}
+	return result.ImdbID, nil
+}
+
+func (t *TMDb) get(reqURL string, out interface{}) error {
+	resp, err := t.httpClient().Get(reqURL)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", reqURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("tmdb request failed with status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,47 @@
+package enrich
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/v-electrolux/kinopoisk-export/model"
+)
+
+// traktDateLayout is the RFC3339 format Trakt's API uses for watched_at.
+const traktDateLayout = "2006-01-02T15:04:05.000Z"
+
+type traktIDs struct {
+	Imdb string `json:"imdb,omitempty"`
+	Tmdb int    `json:"tmdb,omitempty"`
+}
+
+type traktMovie struct {
+	Title     string   `json:"title"`
+	Year      int      `json:"year,omitempty"`
+	IDs       traktIDs `json:"ids"`
+	WatchedAt string   `json:"watched_at,omitempty"`
+	Rating    int      `json:"rating,omitempty"`
+}
+
+// WriteTrakt writes movies as a Trakt-compatible JSON array of
+// {title, year, ids, watched_at, rating} objects, suitable for Trakt's
+// /sync/history and /sync/ratings import endpoints.
+func WriteTrakt(w io.Writer, movies []model.Movie) error {
+	traktMovies := make([]traktMovie, 0, len(movies))
+	for _, movie := range movies {
+		tm := traktMovie{
+			Title:  movie.Title,
+			Year:   movie.Year,
+			IDs:    traktIDs{Imdb: movie.ImdbID, Tmdb: movie.TmdbID},
+			Rating: movie.UserRating,
+		}
+		if movie.WatchDate != nil {
+			tm.WatchedAt = movie.WatchDate.Format(traktDateLayout)
+		}
+		traktMovies = append(traktMovies, tm)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(traktMovies)
+}
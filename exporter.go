@@ -0,0 +1,309 @@
+// Package kinopoisk scrapes a kinopoisk.ru user's "watched" list and can
+// replay it back onto another account. It is built around an Exporter and
+// an Importer, so it can be used as a library as well as from the CLI in
+// cmd/kinopoisk-export.
+package kinopoisk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/v-electrolux/kinopoisk-export/browser"
+	"github.com/v-electrolux/kinopoisk-export/client"
+	"github.com/v-electrolux/kinopoisk-export/enrich"
+	"github.com/v-electrolux/kinopoisk-export/format"
+	"github.com/v-electrolux/kinopoisk-export/model"
+	"github.com/v-electrolux/kinopoisk-export/retry"
+	"github.com/v-electrolux/kinopoisk-export/scraper"
+	"github.com/v-electrolux/kinopoisk-export/store"
+)
+
+// defaultRetries bounds how many attempts are made at a single page
+// (the first fetch included) before Export gives up and returns an error.
+const defaultRetries = 5
+
+// retryBaseDelay is the initial backoff between retries of a failed page.
+const retryBaseDelay = 2 * time.Second
+
+// BrowserChromedp selects the headless-Chrome fallback for Exporter.Browser.
+const BrowserChromedp = "chromedp"
+
+// profileFilmsListSelector is the CSS selector chromedp waits for before
+// considering a votes page fully rendered.
+const profileFilmsListSelector = ".profileFilmsList"
+
+// browserTimeout bounds a single headless-Chrome render, including time
+// for a Cloudflare challenge to resolve.
+const browserTimeout = 30 * time.Second
+
+// EnrichTMDb selects the TMDb id-mapping enrichment for Exporter.Enrich.
+const EnrichTMDb = "tmdb"
+
+// tmdbRequestsPerSecond throttles enrichment lookups to stay well within
+// TMDb's free-tier rate limit.
+const tmdbRequestsPerSecond = 4
+
+// Exporter dumps a user's watched movies from kinopoisk.ru.
+type Exporter struct {
+	// UserID is the kinopoisk numeric user id whose votes list is read.
+	UserID string
+	// Cookie is the raw Cookie header copied from a logged-in browser
+	// session.
+	Cookie string
+	// HTTPClient is used to perform requests. If nil, a client with a
+	// default timeout is used.
+	HTTPClient *http.Client
+	// Output receives the serialized movie collection. Required.
+	Output io.Writer
+	// Format selects the serialization used to write Output ("csv",
+	// "json", "xml" or "tsv"). Defaults to "csv".
+	Format string
+	// DB, if set, receives every scraped movie as the canonical store.
+	// Output is still written from the movies scraped on this run; it is
+	// not read back from the DB.
+	DB *store.Store
+	// Concurrency bounds how many pages are fetched at once. Defaults to 1.
+	Concurrency int
+	// RequestsPerSecond throttles how often any worker may start a new
+	// request. Zero means unthrottled.
+	RequestsPerSecond float64
+	// Browser, when set to BrowserChromedp, renders a page in a headless
+	// Chrome instance whenever a plain HTTP fetch yields no recognisable
+	// content — kinopoisk increasingly fronts its pages with a
+	// Cloudflare challenge a bare http.Get can't get past.
+	Browser string
+	// Enrich, when set to EnrichTMDb, resolves each movie's IMDb and TMDb
+	// id after scraping, for portability to other trackers. Requires
+	// TMDbAPIKey.
+	Enrich string
+	// TMDbAPIKey is a TMDb v3 API key, required when Enrich is set.
+	TMDbAPIKey string
+	// LetterboxdOutput, if set, receives the enriched collection as a
+	// Letterboxd-compatible import CSV. Requires Enrich.
+	LetterboxdOutput io.Writer
+	// TraktOutput, if set, receives the enriched collection as a
+	// Trakt-compatible JSON import. Requires Enrich.
+	TraktOutput io.Writer
+}
+
+func (e *Exporter) client() *client.Client {
+	return &client.Client{Cookie: e.Cookie, HTTPClient: e.HTTPClient}
+}
+
+func (e *Exporter) concurrency() int {
+	if e.Concurrency > 0 {
+		return e.Concurrency
+	}
+	return 1
+}
+
+type pageResult struct {
+	page   int
+	movies []model.Movie
+	err    error
+}
+
+// Export fetches every page of the user's votes list and writes the
+// resulting movie collection to Output.
+func (e *Exporter) Export() error {
+	c := e.client()
+	limiter := newRateLimiter(e.RequestsPerSecond)
+	defer limiter.Stop()
+
+	limiter.Wait()
+	totalMovies, pageSize, err := e.fetchPagingHeader(c, scraper.FirstPageURL(e.UserID))
+	if err != nil {
+		return fmt.Errorf("fetch first page: %w", err)
+	}
+	pageCount := totalMovies / pageSize
+	if totalMovies%pageSize != 0 {
+		pageCount++
+	}
+	fmt.Println("Watched movies count: ", totalMovies)
+	fmt.Println("Page size: ", pageSize)
+	fmt.Println("Pages count: ", pageCount)
+
+	pages := make(chan int)
+	// results is buffered to pageCount so a worker's send never blocks
+	// even if the consumer loop below returns early on a page error —
+	// otherwise the other workers, still draining pages, would leak
+	// blocked forever trying to report their own results.
+	results := make(chan pageResult, pageCount)
+
+	var wg sync.WaitGroup
+	for w := 0; w < e.concurrency(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range pages {
+				var movies []model.Movie
+				err := retry.Do(defaultRetries, retryBaseDelay, scraper.IsRetryable, func() error {
+					limiter.Wait()
+					var fetchErr error
+					movies, fetchErr = e.fetchPage(c, scraper.PageURL(e.UserID, page))
+					return fetchErr
+				})
+				results <- pageResult{page: page, movies: movies, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for i := 1; i <= pageCount; i++ {
+			pages <- i
+		}
+		close(pages)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var movies []model.Movie
+	start := time.Now()
+	parsedPages := 0
+	for res := range results {
+		if res.err != nil {
+			return fmt.Errorf("fetch page %d: %w", res.page, res.err)
+		}
+
+		movies = append(movies, res.movies...)
+		if e.DB != nil {
+			for _, movie := range res.movies {
+				if err := e.DB.Upsert(movie); err != nil {
+					return fmt.Errorf("save page %d: %w", res.page, err)
+				}
+			}
+		}
+
+		parsedPages++
+		reportProgress(parsedPages, pageCount, start)
+	}
+
+	fmt.Println("")
+	fmt.Println("Watched movies parsed: ", len(movies))
+
+	if e.Enrich == EnrichTMDb {
+		movies, err = e.enrichWithTMDb(movies)
+		if err != nil {
+			return fmt.Errorf("enrich with tmdb: %w", err)
+		}
+	}
+
+	if e.LetterboxdOutput != nil {
+		if err := enrich.WriteLetterboxd(e.LetterboxdOutput, movies); err != nil {
+			return fmt.Errorf("write letterboxd csv: %w", err)
+		}
+	}
+	if e.TraktOutput != nil {
+		if err := enrich.WriteTrakt(e.TraktOutput, movies); err != nil {
+			return fmt.Errorf("write trakt json: %w", err)
+		}
+	}
+
+	serializer, err := format.ByName(e.Format)
+	if err != nil {
+		return err
+	}
+	return serializer.Write(e.Output, movies)
+}
+
+// enrichWithTMDb resolves each movie's IMDb and TMDb id, skipping (and
+// logging) movies that TMDb has no match for rather than failing the
+// whole export over one title.
+func (e *Exporter) enrichWithTMDb(movies []model.Movie) ([]model.Movie, error) {
+	tmdb := &enrich.TMDb{APIKey: e.TMDbAPIKey}
+	limiter := newRateLimiter(tmdbRequestsPerSecond)
+	defer limiter.Stop()
+
+	enriched := make([]model.Movie, len(movies))
+	for i, movie := range movies {
+		limiter.Wait()
+		tmdbID, imdbID, err := tmdb.Find(movie.Title, movie.OriginalTitle, movie.Year)
+		switch {
+		case errors.Is(err, enrich.ErrNotFound):
+			fmt.Printf("No TMDb match for %s (%d)\n", movie.Title, movie.Year)
+		case err != nil:
+			return nil, fmt.Errorf("find %s: %w", movie.Title, err)
+		default:
+			movie.TmdbID = tmdbID
+			movie.ImdbID = imdbID
+		}
+		enriched[i] = movie
+	}
+	return enriched, nil
+}
+
+// fetchPage fetches url with a plain HTTP GET, falling back to rendering
+// it in a headless Chrome if that fails with a retryable error (no movies,
+// a 429/5xx status or a network timeout) and e.Browser enables the
+// fallback.
+func (e *Exporter) fetchPage(c *client.Client, url string) ([]model.Movie, error) {
+	movies, err := scraper.ParsePage(c, url)
+	if err == nil || e.Browser != BrowserChromedp || !scraper.IsRetryable(err) {
+		return movies, err
+	}
+
+	rendered, renderErr := browser.Render(context.Background(), url, e.Cookie, profileFilmsListSelector, browserTimeout)
+	if renderErr != nil {
+		return nil, fmt.Errorf("%w (browser fallback failed: %v)", err, renderErr)
+	}
+	return scraper.ParsePageFromHTML(rendered)
+}
+
+// fetchPagingHeader is the paging-header analogue of fetchPage.
+func (e *Exporter) fetchPagingHeader(c *client.Client, url string) (totalMovies, pageSize int, err error) {
+	totalMovies, pageSize, err = scraper.ParsePagingHeader(c, url)
+	if err == nil || e.Browser != BrowserChromedp || !scraper.IsRetryable(err) {
+		return totalMovies, pageSize, err
+	}
+
+	rendered, renderErr := browser.Render(context.Background(), url, e.Cookie, profileFilmsListSelector, browserTimeout)
+	if renderErr != nil {
+		return 0, 0, fmt.Errorf("%w (browser fallback failed: %v)", err, renderErr)
+	}
+	return scraper.ParsePagingHeaderFromHTML(rendered)
+}
+
+// reportProgress prints "parsed/total" progress with an ETA to stderr.
+func reportProgress(done, total int, start time.Time) {
+	elapsed := time.Since(start)
+	var eta time.Duration
+	if done > 0 {
+		eta = elapsed / time.Duration(done) * time.Duration(total-done)
+	}
+	fmt.Fprintf(os.Stderr, "\rparsed %d/%d, ETA %s", done, total, eta.Round(time.Second))
+}
+
+// rateLimiter throttles callers to at most one Wait return per tick, a
+// simple token bucket of size one.
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	if requestsPerSecond <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{ticker: time.NewTicker(time.Duration(float64(time.Second) / requestsPerSecond))}
+}
+
+func (r *rateLimiter) Wait() {
+	if r.ticker == nil {
+		return
+	}
+	<-r.ticker.C
+}
+
+func (r *rateLimiter) Stop() {
+	if r.ticker != nil {
+		r.ticker.Stop()
+	}
+}
@@ -0,0 +1,204 @@
+// Package format serializes and deserializes the exported movie list,
+// letting the same collection be dumped to and read back from CSV, JSON,
+// XML or TSV.
+package format
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/v-electrolux/kinopoisk-export/model"
+)
+
+// watchDateLayout is the date-only layout used for the watch_date column
+// in CSV/TSV, matching the format kinopoisk itself renders dates in.
+const watchDateLayout = "02.01.2006"
+
+// csvColumns is the number of columns in a CSV/TSV row. id and title are
+// kept first for compatibility with the collection's original two-column
+// format.
+const csvColumns = 10
+
+const listSeparator = "|"
+
+// Serializer writes a movie collection to, and reads it back from, a
+// particular file format.
+type Serializer interface {
+	Write(w io.Writer, movies []model.Movie) error
+	Read(r io.Reader) ([]model.Movie, error)
+}
+
+// ByName returns the Serializer registered for the given format name
+// ("csv", "json", "xml" or "tsv").
+func ByName(name string) (Serializer, error) {
+	switch name {
+	case "", "csv":
+		return CSV{}, nil
+	case "tsv":
+		return TSV{}, nil
+	case "json":
+		return JSON{}, nil
+	case "xml":
+		return XML{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", name)
+	}
+}
+
+// CSV serializes movies as semicolon-separated rows.
+type CSV struct{}
+
+func (CSV) Write(w io.Writer, movies []model.Movie) error {
+	return writeDelimited(w, ';', movies)
+}
+
+func (CSV) Read(r io.Reader) ([]model.Movie, error) {
+	return readDelimited(r, ';')
+}
+
+// TSV serializes movies as tab-separated rows.
+type TSV struct{}
+
+func (TSV) Write(w io.Writer, movies []model.Movie) error {
+	return writeDelimited(w, '\t', movies)
+}
+
+func (TSV) Read(r io.Reader) ([]model.Movie, error) {
+	return readDelimited(r, '\t')
+}
+
+func writeDelimited(w io.Writer, comma rune, movies []model.Movie) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	for _, movie := range movies {
+		if err := writer.Write(movieToRecord(movie)); err != nil {
+			return fmt.Errorf("write record: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func readDelimited(r io.Reader, comma rune) ([]model.Movie, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	movies := make([]model.Movie, 0, len(records))
+	for _, record := range records {
+		movies = append(movies, recordToMovie(record))
+	}
+	return movies, nil
+}
+
+// movieToRecord lays movie out as a row of csvColumns fields. Missing optional
+// fields are written as empty columns rather than shrinking the row, so
+// every row has the same shape.
+func movieToRecord(movie model.Movie) []string {
+	record := make([]string, csvColumns)
+	record[0] = movie.ID
+	record[1] = movie.Title
+	record[2] = movie.OriginalTitle
+	if movie.Year != 0 {
+		record[3] = strconv.Itoa(movie.Year)
+	}
+	record[4] = strings.Join(movie.Directors, listSeparator)
+	if movie.UserRating != 0 {
+		record[5] = strconv.Itoa(movie.UserRating)
+	}
+	if movie.WatchDate != nil {
+		record[6] = movie.WatchDate.Format(watchDateLayout)
+	}
+	record[7] = strings.Join(movie.Folders, listSeparator)
+	record[8] = movie.ImdbID
+	if movie.TmdbID != 0 {
+		record[9] = strconv.Itoa(movie.TmdbID)
+	}
+	return record
+}
+
+// recordToMovie reads back a row written by movieToRecord. Older
+// two-column (id;title) files are also accepted.
+func recordToMovie(record []string) model.Movie {
+	get := func(i int) string {
+		if i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	movie := model.Movie{
+		ID:            get(0),
+		Title:         get(1),
+		OriginalTitle: get(2),
+	}
+	if year, err := strconv.Atoi(get(3)); err == nil {
+		movie.Year = year
+	}
+	if directors := get(4); directors != "" {
+		movie.Directors = strings.Split(directors, listSeparator)
+	}
+	if rating, err := strconv.Atoi(get(5)); err == nil {
+		movie.UserRating = rating
+	}
+	if watchDate, err := time.Parse(watchDateLayout, get(6)); err == nil {
+		movie.WatchDate = &watchDate
+	}
+	if folders := get(7); folders != "" {
+		movie.Folders = strings.Split(folders, listSeparator)
+	}
+	movie.ImdbID = get(8)
+	if tmdbID, err := strconv.Atoi(get(9)); err == nil {
+		movie.TmdbID = tmdbID
+	}
+	return movie
+}
+
+// JSON serializes movies as a JSON array of objects.
+type JSON struct{}
+
+func (JSON) Write(w io.Writer, movies []model.Movie) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(movies)
+}
+
+func (JSON) Read(r io.Reader) ([]model.Movie, error) {
+	var movies []model.Movie
+	if err := json.NewDecoder(r).Decode(&movies); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// XML serializes movies as a <movies><movie>...</movie></movies> document.
+type XML struct{}
+
+type xmlMovies struct {
+	XMLName xml.Name      `xml:"movies"`
+	Movies  []model.Movie `xml:"movie"`
+}
+
+func (XML) Write(w io.Writer, movies []model.Movie) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(xmlMovies{Movies: movies})
+}
+
+func (XML) Read(r io.Reader) ([]model.Movie, error) {
+	var doc xmlMovies
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc.Movies, nil
+}
@@ -0,0 +1,104 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/v-electrolux/kinopoisk-export/model"
+)
+
+func sampleMovies() []model.Movie {
+	watchDate := time.Date(2023, time.March, 4, 0, 0, 0, 0, time.UTC)
+	return []model.Movie{
+		{
+			ID:            "123",
+			Title:         "Movie One",
+			OriginalTitle: "Original One",
+			Year:          2012,
+			Directors:     []string{"Director A", "Director B"},
+			UserRating:    8,
+			WatchDate:     &watchDate,
+			Folders:       []string{"Любимые фильмы", "To rewatch"},
+			ImdbID:        "tt0123456",
+			TmdbID:        42,
+		},
+		{
+			ID:    "456",
+			Title: "Movie Two",
+		},
+	}
+}
+
+func TestSerializersRoundTrip(t *testing.T) {
+	for name, serializer := range map[string]Serializer{
+		"csv":  CSV{},
+		"tsv":  TSV{},
+		"json": JSON{},
+		"xml":  XML{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			want := sampleMovies()
+
+			var buf bytes.Buffer
+			if err := serializer.Write(&buf, want); err != nil {
+				t.Fatalf("Write() = %v", err)
+			}
+
+			got, err := serializer.Read(&buf)
+			if err != nil {
+				t.Fatalf("Read() = %v", err)
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("got %d movies, want %d", len(got), len(want))
+			}
+			for i := range want {
+				assertMovieEqual(t, got[i], want[i])
+			}
+		})
+	}
+}
+
+func assertMovieEqual(t *testing.T, got, want model.Movie) {
+	t.Helper()
+	if got.ID != want.ID || got.Title != want.Title || got.OriginalTitle != want.OriginalTitle ||
+		got.Year != want.Year || got.UserRating != want.UserRating ||
+		got.ImdbID != want.ImdbID || got.TmdbID != want.TmdbID {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if !stringSlicesEqual(got.Directors, want.Directors) {
+		t.Fatalf("Directors = %v, want %v", got.Directors, want.Directors)
+	}
+	if !stringSlicesEqual(got.Folders, want.Folders) {
+		t.Fatalf("Folders = %v, want %v", got.Folders, want.Folders)
+	}
+	if (got.WatchDate == nil) != (want.WatchDate == nil) {
+		t.Fatalf("WatchDate = %v, want %v", got.WatchDate, want.WatchDate)
+	}
+	if got.WatchDate != nil && !got.WatchDate.Equal(*want.WatchDate) {
+		t.Fatalf("WatchDate = %v, want %v", got.WatchDate, want.WatchDate)
+	}
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRecordToMovieAcceptsLegacyTwoColumnRows(t *testing.T) {
+	movie := recordToMovie([]string{"789", "Legacy Movie"})
+	if movie.ID != "789" || movie.Title != "Legacy Movie" {
+		t.Fatalf("got %+v, want ID=789 Title=Legacy Movie", movie)
+	}
+	if movie.Year != 0 || movie.WatchDate != nil || movie.Folders != nil {
+		t.Fatalf("got %+v, want zero-value optional fields", movie)
+	}
+}
@@ -0,0 +1,211 @@
+package kinopoisk
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/v-electrolux/kinopoisk-export/client"
+	"github.com/v-electrolux/kinopoisk-export/format"
+	"github.com/v-electrolux/kinopoisk-export/model"
+	"github.com/v-electrolux/kinopoisk-export/retry"
+	"github.com/v-electrolux/kinopoisk-export/scraper"
+	"github.com/v-electrolux/kinopoisk-export/store"
+)
+
+// Importer replays a previously exported movie collection onto a
+// kinopoisk.ru account, marking each movie as watched and restoring its
+// rating and folder membership.
+type Importer struct {
+	// Cookie is the raw Cookie header copied from a logged-in browser
+	// session.
+	Cookie string
+	// HTTPClient is used to perform requests. If nil, a client with a
+	// default timeout is used.
+	HTTPClient *http.Client
+	// Input is the serialized movie collection to replay. Required.
+	Input io.Reader
+	// Format selects the serialization used to read Input ("csv",
+	// "json", "xml" or "tsv"). Defaults to "csv".
+	Format string
+	// DB, if set, is consulted to skip movies already marked watched_set
+	// from a prior run, and updated as movies succeed, so a run
+	// interrupted by a network failure can resume without re-sending
+	// mutations for movies already done.
+	DB *store.Store
+	// Concurrency bounds how many movies are replayed at once. Defaults
+	// to 1.
+	Concurrency int
+	// RequestsPerSecond throttles how often any worker may start a new
+	// request. Zero means unthrottled.
+	RequestsPerSecond float64
+}
+
+func (i *Importer) client() *client.Client {
+	return &client.Client{Cookie: i.Cookie, HTTPClient: i.HTTPClient}
+}
+
+func (i *Importer) concurrency() int {
+	if i.Concurrency > 0 {
+		return i.Concurrency
+	}
+	return 1
+}
+
+type watchResult struct {
+	movie model.Movie
+	ok    bool
+	err   error
+}
+
+// Import reads the movie collection from Input and replays it onto the
+// kinopoisk.ru account identified by Cookie: marking each movie watched,
+// then restoring its rating and folder membership.
+func (i *Importer) Import() error {
+	serializer, err := format.ByName(i.Format)
+	if err != nil {
+		return err
+	}
+
+	movies, err := serializer.Read(i.Input)
+	if err != nil {
+		return fmt.Errorf("read movies: %w", err)
+	}
+
+	var pending []model.Movie
+	for _, movie := range movies {
+		if i.DB != nil {
+			done, err := i.DB.IsWatchedSet(movie.ID)
+			if err != nil {
+				return fmt.Errorf("check %s against db: %w", movie.ID, err)
+			}
+			if done {
+				fmt.Printf("Movie %s already set watched, skipping\n", movie.Title)
+				continue
+			}
+		}
+		pending = append(pending, movie)
+	}
+
+	c := i.client()
+	limiter := newRateLimiter(i.RequestsPerSecond)
+	defer limiter.Stop()
+
+	jobs := make(chan model.Movie)
+	// results is buffered to len(pending) so a worker's send never blocks
+	// even if the consumer loop below returns early on a DB error —
+	// otherwise the other workers, still draining jobs, would leak
+	// blocked forever trying to report their own results.
+	results := make(chan watchResult, len(pending))
+
+	var wg sync.WaitGroup
+	for w := 0; w < i.concurrency(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for movie := range jobs {
+				ok, err := replayMovie(c, limiter, movie)
+				results <- watchResult{movie: movie, ok: ok, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, movie := range pending {
+			jobs <- movie
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	start := time.Now()
+	done := 0
+	for res := range results {
+		done++
+		reportProgress(done, len(pending), start)
+
+		if !res.ok {
+			if res.err != nil {
+				fmt.Println("")
+				fmt.Println("Error replaying movie:", res.err)
+			} else {
+				fmt.Printf("\nMovie %s not set watched\n", res.movie.Title)
+			}
+			continue
+		}
+
+		fmt.Printf("\nMovie %s set watched\n", res.movie.Title)
+		if i.DB != nil {
+			if err := i.DB.MarkWatchedSet(res.movie.ID, time.Now()); err != nil {
+				return fmt.Errorf("mark %s synced: %w", res.movie.ID, err)
+			}
+		}
+		if res.err != nil {
+			fmt.Println("")
+			fmt.Println("Error replaying movie:", res.err)
+		}
+	}
+
+	return nil
+}
+
+// replayMovie marks movie watched and, if present, restores its rating
+// and folder membership. It reports ok=false only when the movie could not
+// be marked watched (an unparseable id, or a failed or rejected "watched"
+// mutation); a failed rating or folder mutation is surfaced as an error
+// with ok=true since the movie was still (partially) restored.
+func replayMovie(c *client.Client, limiter *rateLimiter, movie model.Movie) (bool, error) {
+	movieId, err := strconv.Atoi(movie.ID)
+	if err != nil {
+		return false, fmt.Errorf("convert movie id %q to int: %w", movie.ID, err)
+	}
+
+	var ok bool
+	err = retry.Do(defaultRetries, retryBaseDelay, scraper.IsRetryable, func() error {
+		limiter.Wait()
+		var setErr error
+		ok, setErr = scraper.SetWatched(c, movieId)
+		return setErr
+	})
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	if movie.UserRating != 0 {
+		err := retry.Do(defaultRetries, retryBaseDelay, scraper.IsRetryable, func() error {
+			limiter.Wait()
+			voted, setErr := scraper.SetUserVote(c, movieId, movie.UserRating)
+			if setErr == nil && !voted {
+				setErr = fmt.Errorf("rating rejected")
+			}
+			return setErr
+		})
+		if err != nil {
+			return true, fmt.Errorf("set rating for %s: %w", movie.Title, err)
+		}
+	}
+
+	for _, folder := range movie.Folders {
+		folder := folder
+		err := retry.Do(defaultRetries, retryBaseDelay, scraper.IsRetryable, func() error {
+			limiter.Wait()
+			added, setErr := scraper.AddToFolder(c, movieId, folder)
+			if setErr == nil && !added {
+				setErr = fmt.Errorf("add to folder rejected")
+			}
+			return setErr
+		})
+		if err != nil {
+			return true, fmt.Errorf("add %s to folder %q: %w", movie.Title, folder, err)
+		}
+	}
+
+	return true, nil
+}
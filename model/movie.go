@@ -0,0 +1,30 @@
+// Package model defines the shared representation of a movie in a user's
+// kinopoisk collection, so the scraper, the CSV/JSON/XML/TSV serializers
+// and the SQLite store all agree on one set of fields instead of each
+// carrying its own partial copy. The SQLite store does not persist ImdbID
+// or TmdbID.
+package model
+
+import "time"
+
+// Movie is a single entry in a user's kinopoisk collection: not just the
+// kinopoisk id and title, but enough to round-trip the user's rating and
+// folder membership to another account.
+type Movie struct {
+	ID            string   `json:"id" xml:"id"`
+	Title         string   `json:"title" xml:"title"`
+	OriginalTitle string   `json:"original_title,omitempty" xml:"original_title,omitempty"`
+	Year          int      `json:"year,omitempty" xml:"year,omitempty"`
+	Directors     []string `json:"directors,omitempty" xml:"directors>director,omitempty"`
+	UserRating    int      `json:"user_rating,omitempty" xml:"user_rating,omitempty"`
+	// WatchDate is a pointer so a missing scraped date is actually
+	// omitted by the JSON/XML encoders: omitempty is a no-op on a
+	// non-nil time.Time, which would otherwise serialize the zero date.
+	WatchDate *time.Time `json:"watch_date,omitempty" xml:"watch_date,omitempty"`
+	Folders   []string   `json:"folders,omitempty" xml:"folders>folder,omitempty"`
+	// ImdbID and TmdbID are filled in by a post-export enrichment step
+	// (see the enrich package) that maps a kinopoisk id to its IMDb and
+	// TMDb counterparts, for portability to other trackers.
+	ImdbID string `json:"imdb_id,omitempty" xml:"imdb_id,omitempty"`
+	TmdbID int    `json:"tmdb_id,omitempty" xml:"tmdb_id,omitempty"`
+}
@@ -0,0 +1,34 @@
+// Package retry provides exponential backoff with jitter for operations
+// against flaky upstreams.
+package retry
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Func is an operation that can be retried.
+type Func func() error
+
+// Do calls fn up to attempts times. If fn returns an error for which
+// retryable returns true, Do waits an exponentially increasing backoff
+// (base, 2*base, 4*base, ... plus jitter) and tries again. It returns as
+// soon as fn succeeds, as soon as retryable reports an error as
+// non-retryable, or once attempts is exhausted.
+func Do(attempts int, base time.Duration, retryable func(error) bool, fn Func) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if !retryable(err) || i == attempts-1 {
+			return err
+		}
+
+		delay := base << uint(i)
+		delay += time.Duration(rand.Int63n(int64(base)))
+		time.Sleep(delay)
+	}
+	return err
+}
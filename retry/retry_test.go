@@ -0,0 +1,73 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient")
+
+func alwaysRetryable(error) bool { return true }
+
+func TestDoSucceedsWithoutRetry(t *testing.T) {
+	calls := 0
+	err := Do(3, time.Millisecond, alwaysRetryable, func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() = %v, want nil", err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := Do(3, time.Millisecond, alwaysRetryable, func() error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() = %v, want nil", err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDoStopsOnNonRetryableError(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	calls := 0
+	err := Do(5, time.Millisecond, func(err error) bool {
+		return err != errPermanent
+	}, func() error {
+		calls++
+		return errPermanent
+	})
+	if err != errPermanent {
+		t.Fatalf("Do() = %v, want %v", err, errPermanent)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoReturnsLastErrorWhenExhausted(t *testing.T) {
+	calls := 0
+	err := Do(3, time.Millisecond, alwaysRetryable, func() error {
+		calls++
+		return errTransient
+	})
+	if err != errTransient {
+		t.Fatalf("Do() = %v, want %v", err, errTransient)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
@@ -0,0 +1,52 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrEmptyPage is returned by ParsePage when a page that should contain
+// movies yields none — typically a sign that kinopoisk served a
+// Cloudflare interstitial instead of the votes list. Callers can retry,
+// skip, or abort on it.
+var ErrEmptyPage = errors.New("page yielded no movies")
+
+// ErrNoPagingHeader is returned by ParsePagingHeader when the page did not
+// contain a recognisable "pagesFromTo" header, e.g. because it was a
+// Cloudflare interstitial rather than the votes list.
+var ErrNoPagingHeader = errors.New("page did not contain a paging header")
+
+// HTTPError is returned when kinopoisk responds with a non-2xx status.
+type HTTPError struct {
+	StatusCode int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// Retryable reports whether the error is transient and worth retrying:
+// rate limiting (429) or a server-side failure (5xx).
+func (e *HTTPError) Retryable() bool {
+	return e.StatusCode == 429 || e.StatusCode >= 500
+}
+
+// IsRetryable reports whether err is worth retrying: an HTTPError for
+// which Retryable is true, ErrEmptyPage/ErrNoPagingHeader (the page may
+// render correctly on a subsequent fetch), or a transient network error
+// such as a request timeout or connection reset.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrEmptyPage) || errors.Is(err, ErrNoPagingHeader) {
+		return true
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Retryable()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout() || netErr.Temporary()
+	}
+	return false
+}
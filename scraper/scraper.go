@@ -0,0 +1,442 @@
+// Package scraper knows how to talk to kinopoisk.ru's votes pages and
+// GraphQL endpoint: it fetches and parses the HTML list of watched movies,
+// and can push watched/rating/folder state back for a given movie id.
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/v-electrolux/kinopoisk-export/client"
+	"github.com/v-electrolux/kinopoisk-export/model"
+)
+
+const (
+	SetPageSizeUrlPattern = "https://www.kinopoisk.ru/user/%s/votes/list/vs/novote/perpage/200/"
+	UrlPattern            = "https://www.kinopoisk.ru/user/%s/votes/list/vs/novote/page/%d/"
+
+	GraphqlUrl = "https://graphql.kinopoisk.ru/graphql/"
+
+	MovieSetWatchedOperationName = "MovieSetWatched"
+	MovieSetWatchedQuery         = "mutation MovieSetWatched($movieId: Long!) { movie { watched { set(input: {movieId: $movieId}) { error { message __typename } status __typename } __typename } __typename } } "
+
+	MovieSetUserVoteOperationName = "MovieSetUserVote"
+	MovieSetUserVoteQuery         = "mutation MovieSetUserVote($movieId: Long!, $value: Int!) { movie { vote { set(input: {movieId: $movieId, value: $value}) { error { message __typename } status __typename } __typename } __typename } } "
+
+	MovieAddToFolderOperationName = "MovieAddToFolder"
+	MovieAddToFolderQuery         = "mutation MovieAddToFolder($movieId: Long!, $folderName: String!) { movie { folder { add(input: {movieId: $movieId, folderName: $folderName}) { error { message __typename } status __typename } __typename } __typename } } "
+
+	// watchDateLayout is the "dd.mm.yyyy" format kinopoisk renders the
+	// vote/watch date in next to an item's rating.
+	watchDateLayout = "02.01.2006"
+)
+
+type watchedVariables struct {
+	MovieID int `json:"movieId"`
+}
+
+type userVoteVariables struct {
+	MovieID int `json:"movieId"`
+	Value   int `json:"value"`
+}
+
+type addToFolderVariables struct {
+	MovieID    int    `json:"movieId"`
+	FolderName string `json:"folderName"`
+}
+
+type mutationBody struct {
+	OperationName string      `json:"operationName"`
+	Variables     interface{} `json:"variables"`
+	Query         string      `json:"query"`
+}
+
+type mutationStatus struct {
+	Error  json.RawMessage `json:"error"`
+	Status string          `json:"status"`
+}
+
+// FirstPageURL returns the URL of the first votes page for userId, with the
+// page size bumped to the maximum kinopoisk allows.
+func FirstPageURL(userId string) string {
+	return fmt.Sprintf(SetPageSizeUrlPattern, userId)
+}
+
+// PageURL returns the URL of the given votes page (1-based) for userId.
+func PageURL(userId string, page int) string {
+	if page == 1 {
+		return FirstPageURL(userId)
+	}
+	return fmt.Sprintf(UrlPattern, userId, page)
+}
+
+// ParsePagingHeader fetches url and extracts the total movie count and the
+// page size from the "pagesFromTo" header kinopoisk renders above the list.
+// It returns ErrNoPagingHeader if the page did not contain a recognisable
+// header, e.g. because it was a Cloudflare interstitial rather than the
+// votes list.
+func ParsePagingHeader(c *client.Client, url string) (totalMovies, pageSize int, err error) {
+	resp, err := c.Do(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, 0, &HTTPError{StatusCode: resp.StatusCode}
+	}
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return parsePagingHeaderFromDoc(doc)
+}
+
+// ParsePagingHeaderFromHTML extracts the total movie count and page size
+// from an already-rendered HTML document, as produced by browser.Render.
+func ParsePagingHeaderFromHTML(rendered string) (totalMovies, pageSize int, err error) {
+	doc, err := html.Parse(strings.NewReader(rendered))
+	if err != nil {
+		return 0, 0, err
+	}
+	return parsePagingHeaderFromDoc(doc)
+}
+
+func parsePagingHeaderFromDoc(doc *html.Node) (int, int, error) {
+	totalMovies, pageSize, err := findPagingHeader(doc)
+	if err == nil && totalMovies == 0 {
+		return 0, 0, ErrNoPagingHeader
+	}
+	return totalMovies, pageSize, err
+}
+
+func findPagingHeader(n *html.Node) (int, int, error) {
+	if n.Type == html.ElementNode && n.Data == "div" {
+		if isClassName(n, "pagesFromTo") {
+			pages := n.FirstChild.Data
+			pagesParts := strings.Split(pages, " из ")
+			pageRangeParts := strings.Split(pagesParts[0], "—")
+
+			firstItemNumber, err := strconv.Atoi(pageRangeParts[0])
+			if err != nil {
+				return 0, 0, fmt.Errorf("parse paging header: %w", err)
+			}
+
+			lastItemNumber, err := strconv.Atoi(pageRangeParts[1])
+			if err != nil {
+				return 0, 0, fmt.Errorf("parse paging header: %w", err)
+			}
+			pageSize := lastItemNumber - firstItemNumber + 1
+
+			totalMovies, err := strconv.Atoi(pagesParts[1])
+			if err != nil {
+				return 0, 0, fmt.Errorf("parse paging header: %w", err)
+			}
+			return totalMovies, pageSize, nil
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		totalMovies, pageSize, err := findPagingHeader(c)
+		if totalMovies != 0 || err != nil {
+			return totalMovies, pageSize, err
+		}
+	}
+	return 0, 0, nil
+}
+
+// ParsePage fetches url and returns the movies listed on that votes page.
+// It returns ErrEmptyPage if the page parsed but contained no movies; the
+// caller decides whether that is worth retrying.
+func ParsePage(c *client.Client, url string) ([]model.Movie, error) {
+	resp, err := c.Do(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &HTTPError{StatusCode: resp.StatusCode}
+	}
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	movies := findMovies(doc)
+	if len(movies) == 0 {
+		return nil, ErrEmptyPage
+	}
+	return movies, nil
+}
+
+// ParsePageFromHTML extracts movies from an already-rendered HTML
+// document, as produced by browser.Render.
+func ParsePageFromHTML(rendered string) ([]model.Movie, error) {
+	doc, err := html.Parse(strings.NewReader(rendered))
+	if err != nil {
+		return nil, err
+	}
+
+	movies := findMovies(doc)
+	if len(movies) == 0 {
+		return nil, ErrEmptyPage
+	}
+	return movies, nil
+}
+
+func findMovies(n *html.Node) []model.Movie {
+	if n.Type == html.ElementNode && n.Data == "div" {
+		if isClassName(n, "profileFilmsList") {
+			return processMovies(n)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if movies := findMovies(c); movies != nil {
+			return movies
+		}
+	}
+	return nil
+}
+
+func processMovies(n *html.Node) []model.Movie {
+	var movies []model.Movie
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isClassName(c, "item") || isClassName(c, "item even") {
+			if movie, ok := processMovie(c); ok {
+				movies = append(movies, movie)
+			}
+		}
+	}
+	return movies
+}
+
+func processMovie(n *html.Node) (model.Movie, bool) {
+	var movie model.Movie
+	var found bool
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isClassName(c, "info") {
+			if id, title, ok := findTitleLink(c, "nameRus"); ok {
+				movie.ID = id
+				movie.Title = title
+				found = true
+			}
+			if _, originalTitle, ok := findTitleLink(c, "nameEng"); ok {
+				movie.OriginalTitle = originalTitle
+			}
+			movie.Year = findYear(c)
+			movie.Directors = findDirectors(c)
+		}
+		if isClassName(c, "vote") {
+			movie.UserRating = findRating(c)
+		}
+		if isClassName(c, "date") {
+			movie.WatchDate = findWatchDate(c)
+		}
+		if isClassName(c, "folders") {
+			movie.Folders = findFolders(c)
+		}
+	}
+
+	return movie, found
+}
+
+// findTitleLink finds the <a href="/film/<id>/..."> anchor nested under a
+// div of the given class (nameRus or nameEng) and returns the movie id
+// extracted from its href and its text.
+func findTitleLink(n *html.Node, className string) (id, title string, ok bool) {
+	for d := n.FirstChild; d != nil; d = d.NextSibling {
+		if !isClassName(d, className) {
+			continue
+		}
+		for e := d.FirstChild; e != nil; e = e.NextSibling {
+			if e.Type != html.ElementNode || e.Data != "a" || e.FirstChild == nil {
+				continue
+			}
+			for _, a := range e.Attr {
+				if a.Key == "href" {
+					return extractId(a.Val), e.FirstChild.Data, true
+				}
+			}
+		}
+	}
+	return "", "", false
+}
+
+// findYear finds the release year kinopoisk renders as the first
+// whitespace-separated token of the "year" div, e.g. "2012".
+func findYear(n *html.Node) int {
+	for d := n.FirstChild; d != nil; d = d.NextSibling {
+		if !isClassName(d, "year") || d.FirstChild == nil {
+			continue
+		}
+		fields := strings.Fields(d.FirstChild.Data)
+		if len(fields) == 0 {
+			return 0
+		}
+		year, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return 0
+		}
+		return year
+	}
+	return 0
+}
+
+// findDirectors collects director names from the anchors inside the
+// "director" div.
+func findDirectors(n *html.Node) []string {
+	for d := n.FirstChild; d != nil; d = d.NextSibling {
+		if !isClassName(d, "director") {
+			continue
+		}
+		var directors []string
+		for e := d.FirstChild; e != nil; e = e.NextSibling {
+			if e.Type == html.ElementNode && e.Data == "a" && e.FirstChild != nil {
+				directors = append(directors, e.FirstChild.Data)
+			}
+		}
+		return directors
+	}
+	return nil
+}
+
+// findFolders collects the names of the "My Folders" bookmark lists (e.g.
+// "Любимые фильмы") a movie belongs to, from the anchors inside the
+// "folders" div.
+func findFolders(n *html.Node) []string {
+	var folders []string
+	for e := n.FirstChild; e != nil; e = e.NextSibling {
+		if e.Type == html.ElementNode && e.Data == "a" && e.FirstChild != nil {
+			folders = append(folders, e.FirstChild.Data)
+		}
+	}
+	return folders
+}
+
+// findRating reads the user's numeric vote out of the "vote" div's text.
+func findRating(n *html.Node) int {
+	if n.FirstChild == nil {
+		return 0
+	}
+	rating, err := strconv.Atoi(strings.TrimSpace(n.FirstChild.Data))
+	if err != nil {
+		return 0
+	}
+	return rating
+}
+
+// findWatchDate parses the "dd.mm.yyyy" text of the "date" div. It returns
+// nil if the div was empty or unparseable, rather than the zero time.
+func findWatchDate(n *html.Node) *time.Time {
+	if n.FirstChild == nil {
+		return nil
+	}
+	watchDate, err := time.Parse(watchDateLayout, strings.TrimSpace(n.FirstChild.Data))
+	if err != nil {
+		return nil
+	}
+	return &watchDate
+}
+
+func extractId(href string) string {
+	parts := strings.Split(href, "/")
+	return parts[2]
+}
+
+func isClassName(n *html.Node, className string) bool {
+	for _, a := range n.Attr {
+		if a.Key == "class" && strings.Contains(a.Val, className) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func runMutation(c *client.Client, operationName, query string, variables interface{}) (bool, error) {
+	bodyStruct := mutationBody{
+		OperationName: operationName,
+		Variables:     variables,
+		Query:         query,
+	}
+	body, err := json.Marshal(bodyStruct)
+	if err != nil {
+		return false, fmt.Errorf("marshal body: %w", err)
+	}
+
+	url := fmt.Sprintf("%s?operationName=%s", GraphqlUrl, operationName)
+	resp, err := c.Do(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("fetch url: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, &HTTPError{StatusCode: resp.StatusCode}
+	}
+
+	respByte, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("read response: %w", err)
+	}
+
+	status, err := extractMutationStatus(respByte)
+	if err != nil {
+		return false, fmt.Errorf("parse json: %w", err)
+	}
+
+	return status.Status == "SUCCESS", nil
+}
+
+// extractMutationStatus digs the {error, status} leaf out of a GraphQL
+// response, regardless of which mutation produced it: they all nest it
+// at { data: { movie: { <op>: { <verb>: {...} } } } }, next to the
+// "__typename" strings the queries also select, which are skipped.
+func extractMutationStatus(body []byte) (mutationStatus, error) {
+	var resp struct {
+		Data struct {
+			Movie map[string]json.RawMessage `json:"movie"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return mutationStatus{}, err
+	}
+	for _, op := range resp.Data.Movie {
+		var verbs map[string]json.RawMessage
+		if json.Unmarshal(op, &verbs) != nil {
+			continue
+		}
+		for _, verb := range verbs {
+			var status mutationStatus
+			if json.Unmarshal(verb, &status) == nil {
+				return status, nil
+			}
+		}
+	}
+	return mutationStatus{}, nil
+}
+
+// SetWatched marks movieId as watched on the user's kinopoisk account.
+func SetWatched(c *client.Client, movieId int) (bool, error) {
+	return runMutation(c, MovieSetWatchedOperationName, MovieSetWatchedQuery, watchedVariables{MovieID: movieId})
+}
+
+// SetUserVote sets the user's numeric rating (1-10) for movieId.
+func SetUserVote(c *client.Client, movieId, value int) (bool, error) {
+	return runMutation(c, MovieSetUserVoteOperationName, MovieSetUserVoteQuery, userVoteVariables{MovieID: movieId, Value: value})
+}
+
+// AddToFolder adds movieId to the named folder (e.g. "Любимые фильмы") on
+// the user's kinopoisk account.
+func AddToFolder(c *client.Client, movieId int, folderName string) (bool, error) {
+	return runMutation(c, MovieAddToFolderOperationName, MovieAddToFolderQuery, addToFolderVariables{MovieID: movieId, FolderName: folderName})
+}
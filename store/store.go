@@ -0,0 +1,143 @@
+// Package store provides a SQLite-backed record of every movie exported
+// from, or replayed onto, a kinopoisk.ru account. It is the canonical
+// store: CSV/JSON/XML/TSV are just import/export formats layered on top of
+// it, and it lets a failed import resume without re-sending mutations for
+// movies that were already marked watched.
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/v-electrolux/kinopoisk-export/model"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS movies (
+	id             TEXT PRIMARY KEY,
+	title          TEXT NOT NULL,
+	original_title TEXT,
+	year           INTEGER,
+	directors      TEXT,
+	user_rating    INTEGER,
+	watch_date     DATETIME,
+	folders        TEXT,
+	watched_set    BOOLEAN NOT NULL DEFAULT 0,
+	synced_at      DATETIME
+);
+`
+
+// listSeparator joins Directors/Folders into the single TEXT column
+// sqlite stores them in.
+const listSeparator = "|"
+
+// Store records watched movies in a SQLite database.
+type Store struct {
+	db *sql.DB
+}
+
+// Info summarizes the contents of the store, as printed by -dbinfo.
+type Info struct {
+	MovieCount      int
+	WatchedSetCount int
+	LastSyncedAt    time.Time
+}
+
+// Open opens (creating if necessary) the SQLite database at path and
+// ensures the movies table exists.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Upsert inserts movie into the store, or updates it in place if its id is
+// already present. It does not touch watched_set or synced_at.
+func (s *Store) Upsert(movie model.Movie) error {
+	_, err := s.db.Exec(
+		`INSERT INTO movies (id, title, original_title, year, directors, user_rating, watch_date, folders)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(id) DO UPDATE SET title=excluded.title, original_title=excluded.original_title,
+			year=excluded.year, directors=excluded.directors, user_rating=excluded.user_rating,
+			watch_date=excluded.watch_date, folders=excluded.folders`,
+		movie.ID, movie.Title, movie.OriginalTitle, nullInt(movie.Year), strings.Join(movie.Directors, listSeparator),
+		nullInt(movie.UserRating), nullTime(movie.WatchDate), strings.Join(movie.Folders, listSeparator),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert movie %s: %w", movie.ID, err)
+	}
+	return nil
+}
+
+// IsWatchedSet reports whether id has already been successfully marked
+// watched on a prior run.
+func (s *Store) IsWatchedSet(id string) (bool, error) {
+	var watchedSet bool
+	err := s.db.QueryRow(`SELECT watched_set FROM movies WHERE id = ?`, id).Scan(&watchedSet)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("check watched_set for %s: %w", id, err)
+	}
+	return watchedSet, nil
+}
+
+// MarkWatchedSet records that id was successfully marked watched, stamping
+// synced_at with now.
+func (s *Store) MarkWatchedSet(id string, now time.Time) error {
+	_, err := s.db.Exec(
+		`INSERT INTO movies (id, title, watched_set, synced_at) VALUES (?, '', 1, ?)
+		 ON CONFLICT(id) DO UPDATE SET watched_set=1, synced_at=excluded.synced_at`,
+		id, now,
+	)
+	if err != nil {
+		return fmt.Errorf("mark watched_set for %s: %w", id, err)
+	}
+	return nil
+}
+
+// Info summarizes the store's contents for the -dbinfo subcommand.
+func (s *Store) Info() (Info, error) {
+	var info Info
+	row := s.db.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE watched_set), MAX(synced_at) FROM movies`)
+	var lastSyncedAt sql.NullTime
+	if err := row.Scan(&info.MovieCount, &info.WatchedSetCount, &lastSyncedAt); err != nil {
+		return Info{}, fmt.Errorf("query info: %w", err)
+	}
+	if lastSyncedAt.Valid {
+		info.LastSyncedAt = lastSyncedAt.Time
+	}
+	return info, nil
+}
+
+func nullInt(v int) sql.NullInt64 {
+	if v == 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(v), Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}